@@ -0,0 +1,196 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import "fmt"
+
+// globTokenKind identifies one element of a compiled glob pattern.
+type globTokenKind int
+
+const (
+	globLiteral   globTokenKind = iota // a single literal rune
+	globAny                            // '?': exactly one rune
+	globClass                          // '[...]': one rune out of a set
+	globStar                           // '*': zero or more runes, not crossing '/'
+	globSuperStar                      // '**': zero or more runes, crossing '/'
+)
+
+type globToken struct {
+	kind    globTokenKind
+	literal rune
+	class   globCharClass
+}
+
+type globClassRange struct {
+	lo, hi rune
+}
+
+type globCharClass struct {
+	ranges []globClassRange
+	negate bool
+}
+
+func (c globCharClass) matches(r rune) bool {
+	in := false
+	for _, rg := range c.ranges {
+		if r >= rg.lo && r <= rg.hi {
+			in = true
+			break
+		}
+	}
+	if c.negate {
+		return !in
+	}
+	return in
+}
+
+// FastGlobMatcher compiles a shell-style glob pattern once so that Match can
+// be called repeatedly without re-parsing. Supported syntax:
+//
+//	'*'   matches zero or more runes, but never '/'
+//	'**'  matches zero or more runes, including '/' (multi-segment match)
+//	'?'   matches exactly one rune
+//	[abc], [a-z], [^abc]  matches one rune out of (or not out of) a set
+//
+// Any other rune, including a '\'-escaped metacharacter, is matched
+// literally.
+type FastGlobMatcher struct {
+	pattern string
+	tokens  []globToken
+}
+
+// NewFastGlobMatcher compiles pattern into a FastGlobMatcher.
+func NewFastGlobMatcher(pattern string) (*FastGlobMatcher, error) {
+	tokens, err := compileGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &FastGlobMatcher{pattern: pattern, tokens: tokens}, nil
+}
+
+// GetGlobString returns the pattern the matcher was compiled from.
+func (m *FastGlobMatcher) GetGlobString() string {
+	return m.pattern
+}
+
+// Match reports whether s matches the compiled glob pattern in full.
+func (m *FastGlobMatcher) Match(s string) bool {
+	return globMatch(m.tokens, []rune(s))
+}
+
+func compileGlob(pattern string) ([]globToken, error) {
+	runes := []rune(pattern)
+	tokens := make([]globToken, 0, len(runes))
+
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '\\':
+			i++
+			if i >= len(runes) {
+				return nil, fmt.Errorf("glob: trailing '\\' in pattern %q", pattern)
+			}
+			tokens = append(tokens, globToken{kind: globLiteral, literal: runes[i]})
+		case '?':
+			tokens = append(tokens, globToken{kind: globAny})
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				tokens = append(tokens, globToken{kind: globSuperStar})
+				i++
+			} else {
+				tokens = append(tokens, globToken{kind: globStar})
+			}
+		case '[':
+			class, next, err := compileGlobClass(runes, i+1, pattern)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, globToken{kind: globClass, class: class})
+			i = next
+		default:
+			tokens = append(tokens, globToken{kind: globLiteral, literal: r})
+		}
+	}
+	return tokens, nil
+}
+
+// compileGlobClass parses a "[...]" class starting right after the opening
+// '[' at runes[start], and returns the parsed class plus the index of its
+// closing ']'.
+func compileGlobClass(runes []rune, start int, pattern string) (globCharClass, int, error) {
+	var c globCharClass
+	i := start
+	if i < len(runes) && (runes[i] == '^' || runes[i] == '!') {
+		c.negate = true
+		i++
+	}
+
+	first := true
+	for ; i < len(runes); i++ {
+		if runes[i] == ']' && !first {
+			return c, i, nil
+		}
+		first = false
+
+		lo := runes[i]
+		hi := lo
+		if i+2 < len(runes) && runes[i+1] == '-' && runes[i+2] != ']' {
+			hi = runes[i+2]
+			i += 2
+		}
+		c.ranges = append(c.ranges, globClassRange{lo: lo, hi: hi})
+	}
+	return c, i, fmt.Errorf("glob: unterminated '[' in pattern %q", pattern)
+}
+
+// globMatch tests tokens against s using a straightforward O(len(s) *
+// len(tokens)) dynamic program: dp[i][j] holds whether tokens[j:] matches
+// s[i:]. Every pattern is matched this way, whatever its number of
+// wildcards; the literals between wildcards are not extracted for
+// strings.Index scans the way FastRegexMatcher extracts prefix/suffix
+// literals.
+func globMatch(tokens []globToken, s []rune) bool {
+	n, m := len(s), len(tokens)
+
+	dp := make([][]bool, n+1)
+	for i := range dp {
+		dp[i] = make([]bool, m+1)
+	}
+	dp[n][m] = true
+
+	for j := m - 1; j >= 0; j-- {
+		if tokens[j].kind == globStar || tokens[j].kind == globSuperStar {
+			dp[n][j] = dp[n][j+1]
+		}
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			t := tokens[j]
+			switch t.kind {
+			case globStar:
+				dp[i][j] = dp[i][j+1] || (s[i] != '/' && dp[i+1][j])
+			case globSuperStar:
+				dp[i][j] = dp[i][j+1] || dp[i+1][j]
+			case globAny:
+				dp[i][j] = dp[i+1][j+1]
+			case globClass:
+				dp[i][j] = t.class.matches(s[i]) && dp[i+1][j+1]
+			case globLiteral:
+				dp[i][j] = s[i] == t.literal && dp[i+1][j+1]
+			}
+		}
+	}
+
+	return dp[0][0]
+}
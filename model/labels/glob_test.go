@@ -0,0 +1,96 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastGlobMatcher_Match(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		// Plain literals.
+		{"foo", "foo", true},
+		{"foo", "bar", false},
+		{"foo", "foobar", false},
+
+		// '?' matches exactly one rune.
+		{"fo?", "foo", true},
+		{"fo?", "fo", false},
+		{"fo?", "fooo", false},
+
+		// '*' matches zero or more runes, but not '/'.
+		{"foo*", "foo", true},
+		{"foo*", "foobar", true},
+		{"foo*bar", "foobar", true},
+		{"foo*bar", "foo-baz-bar", true},
+		{"*", "anything", true},
+		{"*", "", true},
+		{"a*b", "a/b", false},
+		{"*", "a/b", false},
+
+		// '**' matches zero or more runes, including '/'.
+		{"a**b", "a/b", true},
+		{"a**b", "a/x/y/b", true},
+		{"**", "a/b/c", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**b", "a/b", true},
+
+		// '[...]' classes.
+		{"[abc]", "a", true},
+		{"[abc]", "b", true},
+		{"[abc]", "d", false},
+		{"[a-z]", "m", true},
+		{"[a-z]", "M", false},
+		{"[a-zA-Z]", "M", true},
+		{"[^abc]", "d", true},
+		{"[^abc]", "a", false},
+		{"[!abc]", "d", true},
+		{"prefix_[a-c]_suffix", "prefix_b_suffix", true},
+		{"prefix_[a-c]_suffix", "prefix_z_suffix", false},
+
+		// '\'-escaping a metacharacter matches it literally.
+		{`\*`, "*", true},
+		{`\*`, "x", false},
+		{`\?`, "?", true},
+		{`\[abc\]`, "[abc]", true},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+	} {
+		t.Run(c.pattern+"/"+c.s, func(t *testing.T) {
+			m, err := NewFastGlobMatcher(c.pattern)
+			require.NoError(t, err)
+			require.Equal(t, c.want, m.Match(c.s))
+			require.Equal(t, c.pattern, m.GetGlobString())
+		})
+	}
+}
+
+func TestFastGlobMatcher_CompileErrors(t *testing.T) {
+	for _, pattern := range []string{
+		"[abc",
+		"foo\\",
+		"[a-",
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			_, err := NewFastGlobMatcher(pattern)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import "fmt"
+
+// MatchType is an enum for label matching types.
+type MatchType int
+
+// Possible MatchTypes.
+const (
+	MatchEqual MatchType = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+	MatchGlob
+	MatchNotGlob
+)
+
+var matchTypeToStr = map[MatchType]string{
+	MatchEqual:     "=",
+	MatchNotEqual:  "!=",
+	MatchRegexp:    "=~",
+	MatchNotRegexp: "!~",
+	MatchGlob:      "=%",
+	MatchNotGlob:   "!%",
+}
+
+func (m MatchType) String() string {
+	if str, ok := matchTypeToStr[m]; ok {
+		return str
+	}
+	panic("unknown match type")
+}
+
+// GlobOperatorsEnabled gates whether ParseMatchType recognizes the =%/!%
+// glob operator tokens. It defaults to false: the glob matcher type exists
+// and works end-to-end once constructed via New, but callers that parse
+// operator tokens from user input (e.g. a PromQL-style parser) should only
+// accept =%/!% once they're ready to document and support it, which this
+// checkout's parser isn't wired up to do yet. Flip this once that wiring
+// exists.
+var GlobOperatorsEnabled = false
+
+// ParseMatchType maps a matcher operator token, as written in a label
+// selector, to its MatchType. It reports false if op isn't a recognized
+// operator, or is =%/!% while GlobOperatorsEnabled is false.
+func ParseMatchType(op string) (MatchType, bool) {
+	switch op {
+	case "=":
+		return MatchEqual, true
+	case "!=":
+		return MatchNotEqual, true
+	case "=~":
+		return MatchRegexp, true
+	case "!~":
+		return MatchNotRegexp, true
+	case "=%":
+		return MatchGlob, GlobOperatorsEnabled
+	case "!%":
+		return MatchNotGlob, GlobOperatorsEnabled
+	default:
+		return 0, false
+	}
+}
+
+// Matcher models the matching of a label.
+type Matcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+
+	re   *FastRegexMatcher
+	glob *FastGlobMatcher
+}
+
+// New returns a matcher object.
+func New(t MatchType, n, v string) (*Matcher, error) {
+	m := &Matcher{
+		Type:  t,
+		Name:  n,
+		Value: v,
+	}
+	switch t {
+	case MatchRegexp, MatchNotRegexp:
+		re, err := NewFastRegexMatcher(v)
+		if err != nil {
+			return nil, err
+		}
+		m.re = re
+	case MatchGlob, MatchNotGlob:
+		g, err := NewFastGlobMatcher(v)
+		if err != nil {
+			return nil, err
+		}
+		m.glob = g
+	}
+	return m, nil
+}
+
+// MustNewMatcher panics if New returns an error. It is intended for tests
+// and other situations where the value is known to be a valid pattern.
+func MustNewMatcher(mt MatchType, name, val string) *Matcher {
+	m, err := New(mt, name, val)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Matches returns whether the matcher matches the given string.
+func (m *Matcher) Matches(s string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return s == m.Value
+	case MatchNotEqual:
+		return s != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(s)
+	case MatchNotRegexp:
+		return !m.re.MatchString(s)
+	case MatchGlob:
+		return m.glob.Match(s)
+	case MatchNotGlob:
+		return !m.glob.Match(s)
+	}
+	panic("labels.Matcher.Matches: invalid match type")
+}
+
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// GetRegexString returns the regex string.
+func (m *Matcher) GetRegexString() string {
+	if m.re == nil {
+		return ""
+	}
+	return m.re.GetRegexString()
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMatchType(t *testing.T) {
+	for _, c := range []struct {
+		op   string
+		want MatchType
+		ok   bool
+	}{
+		{"=", MatchEqual, true},
+		{"!=", MatchNotEqual, true},
+		{"=~", MatchRegexp, true},
+		{"!~", MatchNotRegexp, true},
+		{"<>", 0, false},
+	} {
+		t.Run(c.op, func(t *testing.T) {
+			got, ok := ParseMatchType(c.op)
+			require.Equal(t, c.ok, ok)
+			if ok {
+				require.Equal(t, c.want, got)
+			}
+		})
+	}
+}
+
+func TestParseMatchType_GlobGatedByFeatureFlag(t *testing.T) {
+	orig := GlobOperatorsEnabled
+	defer func() { GlobOperatorsEnabled = orig }()
+
+	GlobOperatorsEnabled = false
+	_, ok := ParseMatchType("=%")
+	require.False(t, ok, "=% should be unrecognized while GlobOperatorsEnabled is false")
+	_, ok = ParseMatchType("!%")
+	require.False(t, ok, "!% should be unrecognized while GlobOperatorsEnabled is false")
+
+	GlobOperatorsEnabled = true
+	got, ok := ParseMatchType("=%")
+	require.True(t, ok)
+	require.Equal(t, MatchGlob, got)
+	got, ok = ParseMatchType("!%")
+	require.True(t, ok)
+	require.Equal(t, MatchNotGlob, got)
+}
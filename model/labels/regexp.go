@@ -28,6 +28,13 @@ type FastRegexMatcher struct {
 	suffixOp syntax.Op
 	contains string
 
+	// setMatches, when non-nil, lists every literal alternative of a regex
+	// like `(foo|bar)`, optionally bracketed by the already extracted
+	// prefix/suffix (e.g. `prefix_(foo|bar)`). MatchString can then test
+	// membership with a single map lookup instead of running m.re.
+	setMatches    []string
+	setMatchesMap map[string]struct{}
+
 	singleOp syntax.Op
 }
 
@@ -48,6 +55,8 @@ func NewFastRegexMatcher(v string) (*FastRegexMatcher, error) {
 
 	if parsed.Op == syntax.OpConcat {
 		m.optimizeConcatRegex(parsed)
+	} else {
+		m.optimizeSetMatches([]*syntax.Regexp{parsed})
 	}
 
 	m.optimizeStartRegex(parsed)
@@ -56,6 +65,20 @@ func NewFastRegexMatcher(v string) (*FastRegexMatcher, error) {
 }
 
 func (m *FastRegexMatcher) MatchString(s string) bool {
+	if m.setMatchesMap != nil {
+		if len(m.prefix)+len(m.suffix) > len(s) {
+			return false
+		}
+		if m.prefix != "" && !strings.HasPrefix(s, m.prefix) {
+			return false
+		}
+		if m.suffix != "" && !strings.HasSuffix(s, m.suffix) {
+			return false
+		}
+		_, ok := m.setMatchesMap[s[len(m.prefix):len(s)-len(m.suffix)]]
+		return ok
+	}
+
 	if m.singleOp == syntax.OpStar {
 		return !strings.Contains(s, "\n")
 	}
@@ -156,5 +179,86 @@ func (m *FastRegexMatcher) optimizeConcatRegex(r *syntax.Regexp) {
 		}
 	}
 
+	m.optimizeSetMatches(sub)
+
 	return
 }
+
+// unwrapCapture strips the capture group(s) a parenthesized alternation like
+// `(foo|bar)` is parsed into, returning the underlying expression.
+func unwrapCapture(r *syntax.Regexp) *syntax.Regexp {
+	for r.Op == syntax.OpCapture {
+		r = r.Sub[0]
+	}
+	return r
+}
+
+// optimizeSetMatches looks for a `(foo|bar)` style alternation of plain
+// literals, optionally bracketed by a literal prefix and/or suffix already
+// identified by the caller (e.g. `prefix_(foo|bar)`, `(foo|bar)_suffix` or
+// `prefix_(foo|bar)_suffix`). When every branch of the alternation is a plain
+// literal, it builds a hash set so that MatchString can test membership
+// directly instead of running the full regexp engine. Mixed alternations
+// (where at least one branch is not a plain literal) are left untouched and
+// fall through to the regexp engine as before.
+//
+// The alternation is examined as syntax.Parse leaves it. The parser factors
+// out text shared by neighbouring branches and merges single-character
+// branches into a character class: `(foo|bar|baz)` becomes `foo|ba[rz]` and
+// `(a|b|c)` becomes `[a-c]`, so neither gets a set and both are matched by
+// the regexp engine.
+func (m *FastRegexMatcher) optimizeSetMatches(sub []*syntax.Regexp) {
+	isPlainLiteral := func(r *syntax.Regexp) bool {
+		return r.Op == syntax.OpLiteral && (r.Flags&syntax.FoldCase) == 0
+	}
+
+	var alt *syntax.Regexp
+
+	switch len(sub) {
+	case 1:
+		alt = unwrapCapture(sub[0])
+	case 2:
+		// Only trust one side to be the whole alternation if the other side
+		// is a plain literal: that's the only case where optimizeConcatRegex
+		// actually folded it into m.prefix/m.suffix above, so it is still
+		// enforced by MatchString. If neither (or both) side is a literal,
+		// e.g. `(foo|bar)(baz|qux)`, nothing here is a no-op constraint and
+		// picking one side would silently drop the other.
+		switch {
+		case isPlainLiteral(sub[0]):
+			alt = unwrapCapture(sub[1])
+		case isPlainLiteral(sub[1]):
+			alt = unwrapCapture(sub[0])
+		default:
+			return
+		}
+	case 3:
+		// Same reasoning: both bracketing sides must be the plain literals
+		// that became m.prefix and m.suffix for the middle alternation to be
+		// the only remaining constraint.
+		if !isPlainLiteral(sub[0]) || !isPlainLiteral(sub[2]) {
+			return
+		}
+		alt = unwrapCapture(sub[1])
+	default:
+		return
+	}
+
+	if alt.Op != syntax.OpAlternate {
+		return
+	}
+
+	matches := make([]string, 0, len(alt.Sub))
+	for _, s := range alt.Sub {
+		if s.Op != syntax.OpLiteral || (s.Flags&syntax.FoldCase) != 0 {
+			return
+		}
+		matches = append(matches, string(s.Rune))
+	}
+
+	m.setMatches = matches
+	m.setMatchesMap = make(map[string]struct{}, len(matches))
+	for _, s := range matches {
+		m.setMatchesMap[s] = struct{}{}
+	}
+}
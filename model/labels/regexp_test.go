@@ -0,0 +1,54 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastRegexMatcher_SetMatches(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		match   string
+		want    bool
+	}{
+		{"foo|bar|baz", "foo", true},
+		{"foo|bar|baz", "qux", false},
+		{"^(foo|bar|baz)$", "bar", true},
+		{"^(foo|bar|baz)$", "barx", false},
+		{"^prefix_(a|b|c)$", "prefix_b", true},
+		{"^prefix_(a|b|c)$", "prefix_d", false},
+		{"^(a|b|c)_suffix$", "a_suffix", true},
+		{"^(a|b|c)_suffix$", "d_suffix", false},
+		{"^pre_(a|b|c)_suf$", "pre_b_suf", true},
+		{"^pre_(a|b|c)_suf$", "pre_b_xuf", false},
+		// Two independent alternations in the same concat, with no literal
+		// prefix or suffix around them: neither alternation alone is the
+		// whole constraint, so each side must still be checked rather than
+		// folded into a single flat set.
+		{"^(foo|bar)(baz|qux)$", "foobaz", true},
+		{"^(foo|bar)(baz|qux)$", "barqux", true},
+		{"^(foo|bar)(baz|qux)$", "foo", false},
+		{"^(foo|bar)(baz|qux)$", "fooqux1", false},
+		{"^(foo|bar)(baz|qux)$", "fooquxbar", false},
+	} {
+		t.Run(c.pattern+"/"+c.match, func(t *testing.T) {
+			m, err := NewFastRegexMatcher(c.pattern)
+			require.NoError(t, err)
+			require.Equal(t, c.want, m.MatchString(c.match))
+		})
+	}
+}
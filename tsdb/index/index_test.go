@@ -0,0 +1,166 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+func writeTestFileKey(t testing.TB, dir, name string) string {
+	t.Helper()
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	p := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(p, key, 0o600))
+	return p
+}
+
+func TestEncryptedSymbolsCodec_WrongKeyFails(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := writeTestFileKey(t, dir, "key")
+	otherKeyPath := writeTestFileKey(t, dir, "other-key")
+
+	writerProvider, err := NewKeyProvider("file:" + keyPath)
+	require.NoError(t, err)
+	w, err := NewEncryptedSymbolsCodec(writerProvider)
+	require.NoError(t, err)
+
+	encoded := w.Encode("some_label_value")
+
+	readerProvider, err := NewKeyProvider("file:" + keyPath)
+	require.NoError(t, err)
+	r, err := OpenEncryptedSymbolsCodec(readerProvider, w.WrappedDEK(), w.NoncePrefix())
+	require.NoError(t, err)
+	require.Equal(t, "some_label_value", r.Decode(encoded))
+
+	wrongProvider, err := NewKeyProvider("file:" + otherKeyPath)
+	require.NoError(t, err)
+	_, err = OpenEncryptedSymbolsCodec(wrongProvider, w.WrappedDEK(), w.NoncePrefix())
+	require.Error(t, err, "opening a block encrypted with a different key should fail")
+}
+
+func TestEncryptedSymbolsCodec_OutOfOrderDecode(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := writeTestFileKey(t, dir, "key")
+
+	writerProvider, err := NewKeyProvider("file:" + keyPath)
+	require.NoError(t, err)
+	w, err := NewEncryptedSymbolsCodec(writerProvider)
+	require.NoError(t, err)
+
+	// The index's symbol table is looked up by offset/binary search, not a
+	// sequential scan, so Decode must work when called out of encode order
+	// and with gaps, the way random-access postings/label-value lookups do.
+	syms := []string{"__name__", "up", "instance", "localhost:9090", "job", "node"}
+	encoded := make([][]byte, len(syms))
+	for i, s := range syms {
+		encoded[i] = w.Encode(s)
+	}
+
+	readerProvider, err := NewKeyProvider("file:" + keyPath)
+	require.NoError(t, err)
+	r, err := OpenEncryptedSymbolsCodec(readerProvider, w.WrappedDEK(), w.NoncePrefix())
+	require.NoError(t, err)
+
+	order := []int{4, 0, 5, 1, 3, 2}
+	for _, i := range order {
+		require.Equal(t, syms[i], r.Decode(encoded[i]))
+	}
+	// Decoding the same symbol twice must also work.
+	require.Equal(t, syms[2], r.Decode(encoded[2]))
+}
+
+func TestEncryptedSymbolsCodec_DecodeSymbolSafelyRecoversFromTamperedInput(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := writeTestFileKey(t, dir, "key")
+
+	provider, err := NewKeyProvider("file:" + keyPath)
+	require.NoError(t, err)
+	codec, err := NewEncryptedSymbolsCodec(provider)
+	require.NoError(t, err)
+
+	encoded := codec.Encode("some_label_value")
+
+	// Flip a bit in the ciphertext, the way a corrupted or tampered block
+	// read from untrusted object storage would show up.
+	tampered := append([]byte(nil), encoded...)
+	tampered[len(tampered)-1] ^= 0xFF
+	require.Panics(t, func() { codec.Decode(tampered) })
+
+	_, err = DecodeSymbolSafely(codec, tampered)
+	require.Error(t, err)
+
+	// A truncated input (too short to even contain the nonce counter) must
+	// also come back as an error, not a panic.
+	_, err = DecodeSymbolSafely(codec, encoded[:4])
+	require.Error(t, err)
+
+	// A well-formed input must still decode normally through the safe path.
+	sym, err := DecodeSymbolSafely(codec, encoded)
+	require.NoError(t, err)
+	require.Equal(t, "some_label_value", sym)
+}
+
+func TestZstdSymbolsCodec_DecodeDoesNotAliasAcrossCalls(t *testing.T) {
+	c := NewZstdSymbolsCodec()
+	c.SampleSize = 1 // train after the first symbol so the rest are dict-compressed
+
+	syms := []string{"__name__", "node_cpu_seconds_total", "instance", "localhost:9100"}
+	encoded := make([][]byte, len(syms))
+	for i, s := range syms {
+		encoded[i] = c.Encode(s)
+	}
+
+	// Decode every symbol up front and hold onto all the results, the way
+	// Symbols() does, before checking any of them: a codec that reuses one
+	// scratch buffer across Decode calls would corrupt the earlier strings.
+	decoded := make([]string, len(encoded))
+	for i, e := range encoded {
+		decoded[i] = c.Decode(e)
+	}
+	for i, s := range syms {
+		require.Equal(t, s, decoded[i])
+	}
+}
+
+func BenchmarkWriteIndex_EncryptedSymbols(b *testing.B) {
+	lbls, err := labels.ReadLabels(filepath.Join("..", "testdata", "20kseries.json"), 5000)
+	require.NoError(b, err)
+
+	dir := b.TempDir()
+	keyPath := writeTestFileKey(b, dir, "key")
+
+	provider, err := NewKeyProvider("file:" + keyPath)
+	require.NoError(b, err)
+	codec, err := NewEncryptedSymbolsCodec(provider)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, lset := range lbls {
+			for _, l := range lset {
+				codec.Encode(l.Name)
+				codec.Encode(l.Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	// dekSize is the size in bytes of the per-block AES-256 DEK.
+	dekSize = 32
+	// noncePrefixSize is the size in bytes of the random per-block nonce
+	// prefix; the remaining bytes of each AES-GCM nonce are an
+	// incrementing counter, so noncePrefixSize+8 must equal the GCM
+	// standard nonce size of 12 bytes.
+	noncePrefixSize = 4
+)
+
+func aesGCMSeal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func aesGCMOpen(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted index: wrapped DEK shorter than a nonce")
+	}
+	nonce, ct := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ct, nil)
+}
+
+// EncryptedSymbolsCodec is a SymbolsCodec that encrypts every symbol with
+// AES-GCM under a per-block data-encryption-key (DEK), so that TSDB blocks
+// shipped to untrusted object storage don't leak label names or values.
+//
+// The DEK is generated randomly per block and sealed with a KeyProvider;
+// WrappedDEK and NoncePrefix are what the index writer must persist in a
+// dedicated section of the index file, and must be read back and passed to
+// OpenEncryptedSymbolsCodec before the reader's first Decode call.
+//
+// Each symbol is encrypted with a nonce built from the block's random
+// prefix and a monotonically increasing counter, so Encode never needs to
+// read back previously written state: as long as a codec instance is only
+// ever used for a single block's symbol table, the counter guarantees the
+// nonce is never reused. The counter is prefixed onto each symbol's
+// ciphertext (like symbols_zstd.go prefixes a tag byte) so Decode can
+// rebuild the exact nonce Encode used without relying on being called in
+// the same order: the index's symbol table is looked up by offset and
+// binary search, not a sequential scan, so Decode has no guaranteed call
+// order to derive a counter from on its own.
+type EncryptedSymbolsCodec struct {
+	dek         []byte
+	noncePrefix []byte
+	wrappedDEK  []byte
+
+	counter atomic.Uint64
+}
+
+// NewEncryptedSymbolsCodec creates a codec that generates a fresh DEK and
+// seals it with provider. Use this on the write path.
+func NewEncryptedSymbolsCodec(provider KeyProvider) (*EncryptedSymbolsCodec, error) {
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	noncePrefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(noncePrefix); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := provider.WrapDEK(dek)
+	if err != nil {
+		return nil, fmt.Errorf("encrypted index: wrapping DEK: %w", err)
+	}
+
+	return &EncryptedSymbolsCodec{
+		dek:         dek,
+		noncePrefix: noncePrefix,
+		wrappedDEK:  wrapped,
+	}, nil
+}
+
+// OpenEncryptedSymbolsCodec creates a codec for the read path: it unwraps
+// wrappedDEK (and the accompanying noncePrefix, both read from the index
+// file's encrypted-symbols section) with provider up front, so each Decode
+// call is a single AES-GCM open. It returns an error if wrappedDEK was not
+// sealed with a key provider compatible to provider.
+func OpenEncryptedSymbolsCodec(provider KeyProvider, wrappedDEK, noncePrefix []byte) (*EncryptedSymbolsCodec, error) {
+	dek, err := provider.UnwrapDEK(wrappedDEK)
+	if err != nil {
+		return nil, fmt.Errorf("encrypted index: unwrapping DEK: %w", err)
+	}
+	return &EncryptedSymbolsCodec{
+		dek:         dek,
+		noncePrefix: noncePrefix,
+		wrappedDEK:  wrappedDEK,
+	}, nil
+}
+
+// WrappedDEK returns the sealed DEK that must be persisted alongside
+// NoncePrefix in the index file's encrypted-symbols section.
+func (c *EncryptedSymbolsCodec) WrappedDEK() []byte { return c.wrappedDEK }
+
+// NoncePrefix returns the random per-block nonce prefix that must be
+// persisted alongside WrappedDEK.
+func (c *EncryptedSymbolsCodec) NoncePrefix() []byte { return c.noncePrefix }
+
+func (c *EncryptedSymbolsCodec) nonce(counter uint64) []byte {
+	nonce := make([]byte, noncePrefixSize+8)
+	copy(nonce, c.noncePrefix)
+	binary.BigEndian.PutUint64(nonce[noncePrefixSize:], counter)
+	return nonce
+}
+
+func (c *EncryptedSymbolsCodec) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.dek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encode implements SymbolsCodec. The returned bytes are prefixed with the
+// 8-byte counter used to derive the nonce, so Decode can recover it without
+// needing to be called in encode order.
+func (c *EncryptedSymbolsCodec) Encode(sym string) []byte {
+	gcm, err := c.cipher()
+	if err != nil {
+		panic(err)
+	}
+	counter := c.counter.Add(1) - 1
+	ciphertext := gcm.Seal(nil, c.nonce(counter), []byte(sym), nil)
+
+	out := make([]byte, 8+len(ciphertext))
+	binary.BigEndian.PutUint64(out, counter)
+	copy(out[8:], ciphertext)
+	return out
+}
+
+// Decode implements SymbolsCodec. Symbols may be decoded in any order: the
+// nonce is rebuilt from the counter prefixed onto b by Encode, not from how
+// many times Decode has been called.
+//
+// Decode panics on a short input or a GCM authentication failure, same as
+// every other SymbolsCodec in this package panics on malformed input: the
+// Decode(b []byte) string hook has no error return, so there is no other
+// way to report it through this interface. For this codec specifically
+// that trade-off is worth calling out: its whole premise is guarding
+// blocks shipped to untrusted object storage, and bit-flipped or truncated
+// ciphertext is exactly what tampering with such a block looks like.
+// Callers reading symbols from an untrusted block should use
+// DecodeSymbolSafely (or their own recover) at whatever boundary they want
+// one corrupted symbol to be contained to, e.g. failing to open that one
+// block, rather than letting the panic propagate out of the whole read
+// path.
+func (c *EncryptedSymbolsCodec) Decode(b []byte) string {
+	if len(b) < 8 {
+		panic(fmt.Errorf("encrypted index: encoded symbol too short to contain a nonce counter"))
+	}
+	counter := binary.BigEndian.Uint64(b[:8])
+
+	gcm, err := c.cipher()
+	if err != nil {
+		panic(err)
+	}
+	out, err := gcm.Open(nil, c.nonce(counter), b[8:], nil)
+	if err != nil {
+		panic(fmt.Errorf("encrypted index: decode symbol: %w", err))
+	}
+	return string(out)
+}
+
+// DecodeSymbolSafely calls codec.Decode and recovers from any panic,
+// turning it into an error instead. Use this instead of calling Decode
+// directly when decoding symbols from a block that may be corrupted or
+// tampered with (e.g. EncryptedSymbolsCodec reading from untrusted object
+// storage), so that one bad symbol fails just the decode instead of
+// bringing down the whole reading process.
+func DecodeSymbolSafely(codec SymbolsCodec, b []byte) (sym string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("decode symbol: %v", r)
+		}
+	}()
+	return codec.Decode(b), nil
+}
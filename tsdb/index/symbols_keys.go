@@ -0,0 +1,179 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	jose "github.com/go-jose/go-jose/v4"
+)
+
+// KeyProvider seals and opens a per-block data-encryption-key (DEK) for
+// EncryptedSymbolsCodec. The wrapped DEK returned by WrapDEK is what gets
+// persisted in the index file's encrypted-symbols section; UnwrapDEK
+// recovers the original DEK from it on the read path.
+type KeyProvider interface {
+	WrapDEK(dek []byte) (wrapped []byte, err error)
+	UnwrapDEK(wrapped []byte) (dek []byte, err error)
+}
+
+// KeyProviderFactory builds a KeyProvider from the scheme-specific part of a
+// key URI, i.e. the URI with its "<scheme>:" prefix removed.
+type KeyProviderFactory func(rest string) (KeyProvider, error)
+
+var (
+	keyProviderMu  sync.RWMutex
+	keyProviderReg = map[string]KeyProviderFactory{
+		"file": newFileKeyProvider,
+		"jwe":  newJWEKeyProvider,
+	}
+)
+
+// RegisterKeyProvider registers a KeyProviderFactory for the given URI
+// scheme (e.g. "kms"), so that external packages can plug in their own key
+// management (e.g. a cloud KMS client) without this package depending on
+// them directly.
+func RegisterKeyProvider(scheme string, factory KeyProviderFactory) {
+	keyProviderMu.Lock()
+	defer keyProviderMu.Unlock()
+	keyProviderReg[scheme] = factory
+}
+
+// NewKeyProvider builds a KeyProvider from a URI such as
+// "file:/path/to/key" or "jwe:/path/to/key.pem". The scheme selects the
+// factory registered via RegisterKeyProvider (or one of the built-in
+// "file"/"jwe" providers); everything after the first colon is passed to it
+// verbatim.
+func NewKeyProvider(uri string) (KeyProvider, error) {
+	scheme, rest, ok := strings.Cut(uri, ":")
+	if !ok {
+		return nil, fmt.Errorf("encrypted index: key URI %q has no scheme", uri)
+	}
+
+	keyProviderMu.RLock()
+	factory, ok := keyProviderReg[scheme]
+	keyProviderMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("encrypted index: unknown key provider scheme %q", scheme)
+	}
+	return factory(rest)
+}
+
+// fileKeyProvider wraps DEKs with AES-GCM under a raw key loaded from a
+// local file.
+type fileKeyProvider struct {
+	key []byte
+}
+
+func newFileKeyProvider(path string) (KeyProvider, error) {
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("encrypted index: reading key file: %w", err)
+	}
+	return &fileKeyProvider{key: key}, nil
+}
+
+func (p *fileKeyProvider) WrapDEK(dek []byte) ([]byte, error) {
+	return aesGCMSeal(p.key, dek)
+}
+
+func (p *fileKeyProvider) UnwrapDEK(wrapped []byte) ([]byte, error) {
+	return aesGCMOpen(p.key, wrapped)
+}
+
+// jweKeyProvider wraps DEKs as a compact JWE (RSA-OAEP-256 key wrap,
+// A256GCM content encryption) under an RSA key pair loaded from a PEM file.
+// The writer side needs the recipient's public key; the reader side needs
+// the private key that matches it.
+type jweKeyProvider struct {
+	pub  *rsa.PublicKey
+	priv *rsa.PrivateKey
+}
+
+func newJWEKeyProvider(path string) (KeyProvider, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("encrypted index: reading jwe key file: %w", err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("encrypted index: %s does not contain PEM data", path)
+	}
+
+	p := &jweKeyProvider{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("encrypted index: parsing public key: %w", err)
+		}
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("encrypted index: jwe key provider only supports RSA keys")
+		}
+		p.pub = pub
+	case "RSA PRIVATE KEY":
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("encrypted index: parsing private key: %w", err)
+		}
+		p.priv, p.pub = priv, &priv.PublicKey
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("encrypted index: parsing private key: %w", err)
+		}
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("encrypted index: jwe key provider only supports RSA keys")
+		}
+		p.priv, p.pub = priv, &priv.PublicKey
+	default:
+		return nil, fmt.Errorf("encrypted index: unsupported PEM block type %q", block.Type)
+	}
+	return p, nil
+}
+
+func (p *jweKeyProvider) WrapDEK(dek []byte) ([]byte, error) {
+	if p.pub == nil {
+		return nil, fmt.Errorf("encrypted index: jwe key provider has no public key to wrap with")
+	}
+	enc, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: jose.RSA_OAEP_256, Key: p.pub}, nil)
+	if err != nil {
+		return nil, err
+	}
+	jwe, err := enc.Encrypt(dek)
+	if err != nil {
+		return nil, err
+	}
+	out, err := jwe.CompactSerialize()
+	return []byte(out), err
+}
+
+func (p *jweKeyProvider) UnwrapDEK(wrapped []byte) ([]byte, error) {
+	if p.priv == nil {
+		return nil, fmt.Errorf("encrypted index: jwe key provider has no private key to unwrap with")
+	}
+	jwe, err := jose.ParseEncryptedCompact(string(wrapped), []jose.KeyAlgorithm{jose.RSA_OAEP_256}, []jose.ContentEncryption{jose.A256GCM})
+	if err != nil {
+		return nil, err
+	}
+	return jwe.Decrypt(p.priv)
+}
@@ -41,8 +41,20 @@ func NewNoOpPool(makeFunc func(int) interface{}) Pool {
 	return &NoOpPool{make: makeFunc}
 }
 
-// BucketedPool is a bucketed pool for variably sized byte slices.
-type BucketedPool struct {
+// bucketedPool is the Pool implementation returned by NewBucketedPool. It
+// predates generics and still relies on reflection, since the Pool
+// interface itself is typed in terms of interface{}; typed callers should
+// use BucketedPool[T] instead, which avoids the reflection and boxing
+// entirely.
+//
+// It cannot simply delegate to BucketedPool[T]: NewBucketedPool isn't
+// itself generic (changing that would break every existing call site,
+// which passes a plain func(int) interface{}), and once a slice has
+// crossed the Pool interface as an interface{}, its concrete element type
+// is erased, so there is no single T a shared BucketedPool[T] instance
+// could hold it as. The two implementations do share their bucket-index
+// math via bucketIndex, so that part at least can't drift out of sync.
+type bucketedPool struct {
 	buckets []sync.Pool
 	sizes   []int
 	// make is the function used to create an empty slice when none exist yet.
@@ -52,23 +64,9 @@ type BucketedPool struct {
 // NewBucketedPool returns a new Pool with size buckets for minSize to maxSize
 // increasing by the given factor.
 func NewBucketedPool(minSize, maxSize int, factor float64, makeFunc func(int) interface{}) Pool {
-	if minSize < 1 {
-		panic("invalid minimum pool size")
-	}
-	if maxSize < 1 {
-		panic("invalid maximum pool size")
-	}
-	if factor < 1 {
-		panic("invalid factor")
-	}
-
-	var sizes []int
-
-	for s := minSize; s <= maxSize; s = int(float64(s) * factor) {
-		sizes = append(sizes, s)
-	}
+	sizes := bucketSizes(minSize, maxSize, factor)
 
-	p := &BucketedPool{
+	p := &bucketedPool{
 		buckets: make([]sync.Pool, len(sizes)),
 		sizes:   sizes,
 		make:    makeFunc,
@@ -78,32 +76,114 @@ func NewBucketedPool(minSize, maxSize int, factor float64, makeFunc func(int) in
 }
 
 // Get returns a new byte slices that fits the given size.
-func (p *BucketedPool) Get(sz int) interface{} {
-	for i, bktSize := range p.sizes {
-		if sz > bktSize {
-			continue
-		}
-		b := p.buckets[i].Get()
-		if b == nil {
-			b = p.make(bktSize)
-		}
-		return b
+func (p *bucketedPool) Get(sz int) interface{} {
+	i := bucketIndex(p.sizes, sz)
+	if i == len(p.sizes) {
+		return p.make(sz)
+	}
+	b := p.buckets[i].Get()
+	if b == nil {
+		b = p.make(p.sizes[i])
 	}
-	return p.make(sz)
+	return b
 }
 
-// Put adds a slice to the right bucket in the pool.
-func (p *BucketedPool) Put(s interface{}) {
+// Put adds a slice to the right bucket in the pool, or drops it if its
+// capacity exceeds the largest bucket.
+func (p *bucketedPool) Put(s interface{}) {
 	slice := reflect.ValueOf(s)
 
 	if slice.Kind() != reflect.Slice {
 		panic(fmt.Sprintf("%+v is not a slice", slice))
 	}
-	for i, size := range p.sizes {
-		if slice.Cap() > size {
-			continue
-		}
-		p.buckets[i].Put(slice.Slice(0, 0).Interface())
+	i := bucketIndex(p.sizes, slice.Cap())
+	if i == len(p.sizes) {
 		return
 	}
+	p.buckets[i].Put(slice.Slice(0, 0).Interface())
+}
+
+// BucketedPool is a bucketed pool for variably sized slices of T. It keeps
+// the same per-bucket sync.Pool design as the reflection-based Pool
+// implementation above, but stores []T directly so Get and Put avoid the
+// reflect.Value and interface{} boxing that shows up in CPU profiles for
+// hot paths that churn byte/float64 slices.
+//
+// Migrating the actual reflect-based callers this type exists to help
+// (tsdb/chunks, the postings decoders, remote-write buffers) to
+// NewGenericBucketedPool is still outstanding: none of those packages are
+// part of this checkout (only model/labels, tsdb/index and this package
+// are), so there is nothing here to repoint at the generic pool yet. The
+// migration is the next step once they're available.
+type BucketedPool[T any] struct {
+	buckets []sync.Pool
+	sizes   []int
+	// make is the function used to create an empty slice when none exist yet.
+	make func(int) []T
+}
+
+// NewGenericBucketedPool returns a new BucketedPool[T] with size buckets for
+// minSize to maxSize increasing by the given factor.
+func NewGenericBucketedPool[T any](minSize, maxSize int, factor float64, makeFunc func(int) []T) *BucketedPool[T] {
+	sizes := bucketSizes(minSize, maxSize, factor)
+
+	return &BucketedPool[T]{
+		buckets: make([]sync.Pool, len(sizes)),
+		sizes:   sizes,
+		make:    makeFunc,
+	}
+}
+
+// Get returns a slice that fits the given size.
+func (p *BucketedPool[T]) Get(sz int) []T {
+	i := bucketIndex(p.sizes, sz)
+	if i == len(p.sizes) {
+		return p.make(sz)
+	}
+	if b, ok := p.buckets[i].Get().([]T); ok {
+		return b
+	}
+	return p.make(p.sizes[i])
+}
+
+// Put adds a slice to the right bucket in the pool, or drops it if its
+// capacity exceeds the largest bucket.
+func (p *BucketedPool[T]) Put(s []T) {
+	i := bucketIndex(p.sizes, cap(s))
+	if i == len(p.sizes) {
+		return
+	}
+	p.buckets[i].Put(s[:0])
+}
+
+// bucketIndex returns the index of the smallest bucket able to hold n
+// units, or len(sizes) if none of them are large enough. It is the one
+// piece of bucket-selection logic bucketedPool and BucketedPool[T] share.
+func bucketIndex(sizes []int, n int) int {
+	for i, size := range sizes {
+		if n <= size {
+			return i
+		}
+	}
+	return len(sizes)
+}
+
+// bucketSizes computes the bucket boundaries shared by bucketedPool and
+// BucketedPool[T].
+func bucketSizes(minSize, maxSize int, factor float64) []int {
+	if minSize < 1 {
+		panic("invalid minimum pool size")
+	}
+	if maxSize < 1 {
+		panic("invalid maximum pool size")
+	}
+	if factor < 1 {
+		panic("invalid factor")
+	}
+
+	var sizes []int
+	for s := minSize; s <= maxSize; s = int(float64(s) * factor) {
+		sizes = append(sizes, s)
+	}
+	return sizes
 }
@@ -0,0 +1,93 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketedPool_PutDropsOverCapacitySlice(t *testing.T) {
+	pool := NewBucketedPool(1, 8, 2, func(sz int) interface{} {
+		return make([]byte, 0, sz)
+	})
+
+	// Bucket boundaries here are 1, 2, 4, 8; a slice with capacity 16
+	// doesn't fit any of them and must be dropped, not stuffed into the
+	// largest (8) bucket where it would never shrink back down.
+	pool.Put(make([]byte, 0, 16))
+
+	got := pool.Get(8).([]byte)
+	require.Equal(t, 8, cap(got), "Get(8) should have gotten a freshly made slice, not the oversized Put one")
+}
+
+func TestGenericBucketedPool_PutDropsOverCapacitySlice(t *testing.T) {
+	pool := NewGenericBucketedPool(1, 8, 2, func(sz int) []byte {
+		return make([]byte, 0, sz)
+	})
+
+	pool.Put(make([]byte, 0, 16))
+
+	got := pool.Get(8)
+	require.Equal(t, 8, cap(got), "Get(8) should have gotten a freshly made slice, not the oversized Put one")
+}
+
+func BenchmarkBucketedPool_Bytes(b *testing.B) {
+	pool := NewBucketedPool(8, 32*1024, 2, func(sz int) interface{} {
+		return make([]byte, 0, sz)
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := pool.Get(1024).([]byte)
+		pool.Put(s)
+	}
+}
+
+func BenchmarkGenericBucketedPool_Bytes(b *testing.B) {
+	pool := NewGenericBucketedPool(8, 32*1024, 2, func(sz int) []byte {
+		return make([]byte, 0, sz)
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := pool.Get(1024)
+		pool.Put(s)
+	}
+}
+
+func BenchmarkBucketedPool_Float64s(b *testing.B) {
+	pool := NewBucketedPool(8, 32*1024, 2, func(sz int) interface{} {
+		return make([]float64, 0, sz)
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := pool.Get(1024).([]float64)
+		pool.Put(s)
+	}
+}
+
+func BenchmarkGenericBucketedPool_Float64s(b *testing.B) {
+	pool := NewGenericBucketedPool(8, 32*1024, 2, func(sz int) []float64 {
+		return make([]float64, 0, sz)
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := pool.Get(1024)
+		pool.Put(s)
+	}
+}